@@ -0,0 +1,210 @@
+package whatsmyip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheFile is the on-disk representation of a Cache's last known state.
+type cacheFile struct {
+	IP        string    `json:"ip"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache persists the machine's last known external IP address, together
+// with its source and fetch time, to a JSON file on disk. It notifies
+// registered OnChange callbacks whenever Refresh observes the address
+// change, so callers can drive DDNS updaters, webhook posts, firewall
+// reconfiguration, or similar.
+type Cache struct {
+	mu        sync.RWMutex
+	path      string
+	ip        net.IP
+	source    string
+	fetchedAt time.Time
+	onChange  []func(old, new net.IP)
+}
+
+// defaultCachePath returns the default cache file location, under
+// os.UserCacheDir().
+func defaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("whatsmyip: resolving default cache dir: %w", err)
+	}
+	return filepath.Join(dir, "whatsmyip", "cache.json"), nil
+}
+
+// NewCache returns a Cache backed by the JSON file at path. If path is
+// empty, a default location under os.UserCacheDir() is used. Any
+// previously persisted state at that path is loaded immediately; a missing
+// or unreadable file is not an error, it just starts the Cache empty.
+func NewCache(path string) (*Cache, error) {
+	if path == "" {
+		p, err := defaultCachePath()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+
+	c := &Cache{path: path}
+	c.load()
+	return c, nil
+}
+
+// load populates the Cache from its on-disk file, if present and valid.
+func (c *Cache) load() {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+
+	var cf cacheFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return
+	}
+
+	ip := net.ParseIP(cf.IP)
+	if ip == nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.ip = ip
+	c.source = cf.Source
+	c.fetchedAt = cf.FetchedAt
+	c.mu.Unlock()
+}
+
+// save writes the Cache's current state to its file atomically, via a
+// temp file in the same directory followed by a rename.
+func (c *Cache) save() error {
+	c.mu.RLock()
+	cf := cacheFile{IP: c.ip.String(), Source: c.source, FetchedAt: c.fetchedAt}
+	c.mu.RUnlock()
+
+	data, err := json.MarshalIndent(cf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("whatsmyip: encoding cache: %w", err)
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("whatsmyip: creating cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".whatsmyip-cache-*.tmp")
+	if err != nil {
+		return fmt.Errorf("whatsmyip: creating temp cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("whatsmyip: writing temp cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("whatsmyip: closing temp cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("whatsmyip: renaming temp cache file: %w", err)
+	}
+	return nil
+}
+
+// OnChange registers fn to be called whenever Refresh observes the cached
+// IP address change. fn receives the previous and new address; old is nil
+// the first time an address is observed.
+func (c *Cache) OnChange(fn func(old, new net.IP)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, fn)
+}
+
+// IP returns the most recently cached IP address, or nil if Refresh has
+// never succeeded.
+func (c *Cache) IP() net.IP {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ip
+}
+
+// Source returns the name of the Provider that supplied the most recently
+// cached IP address, or the empty string if Refresh has never succeeded.
+func (c *Cache) Source() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.source
+}
+
+// Refresh fetches the current external IP address via Get, persists it to
+// disk if it differs from the cached value, and invokes every registered
+// OnChange callback when a change is observed. ctx bounds the underlying
+// fetch: a caller-provided timeout or cancellation is honored instead of
+// being silently ignored.
+func (c *Cache) Refresh(ctx context.Context) error {
+	ip, source, err := getContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old := c.ip
+	changed := old == nil || !old.Equal(ip)
+	c.ip = ip
+	c.source = source
+	c.fetchedAt = time.Now()
+	callbacks := append([]func(net.IP, net.IP){}, c.onChange...)
+	c.mu.Unlock()
+
+	if err := c.save(); err != nil {
+		return err
+	}
+
+	if changed {
+		for _, fn := range callbacks {
+			fn(old, ip)
+		}
+	}
+	return nil
+}
+
+// Start runs Refresh once immediately and then every interval, in its own
+// goroutine, until the returned context.CancelFunc is called. onRefresh, if
+// non-nil, is called after every Refresh attempt with its duration and
+// result (nil on success), so callers can report metrics or otherwise
+// observe individual attempts; Refresh errors are also always logged and do
+// not stop the loop.
+func (c *Cache) Start(interval time.Duration, onRefresh func(time.Duration, error)) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for {
+			start := time.Now()
+			err := c.Refresh(ctx)
+			if err != nil {
+				log.Warn("cache refresh failed", "error", err)
+			}
+			if onRefresh != nil {
+				onRefresh(time.Since(start), err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	return cancel
+}
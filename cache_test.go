@@ -0,0 +1,138 @@
+package whatsmyip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProvider is a Provider that returns a fixed IP address without making
+// any network request, used to exercise Get and its callers hermetically.
+type fakeProvider struct {
+	name string
+	ip   net.IP
+}
+
+func (p fakeProvider) Name() string { return p.name }
+
+func (p fakeProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	return p.ip, nil
+}
+
+// withFakeRegistry swaps the default Registry and scheduler for empty ones
+// seeded with providers, for the duration of the calling test, so Get and
+// its callers can be exercised without hitting the real internet or being
+// affected by circuit-breaker state left by another test.
+func withFakeRegistry(t *testing.T, providers ...Provider) {
+	t.Helper()
+	origRegistry, origScheduler := defaultRegistry, defaultScheduler
+	defaultRegistry = NewRegistry()
+	defaultScheduler = &scheduler{stats: make(map[string]*providerStats)}
+	for _, p := range providers {
+		defaultRegistry.Register(p)
+	}
+	t.Cleanup(func() {
+		defaultRegistry = origRegistry
+		defaultScheduler = origScheduler
+	})
+}
+
+// Test Cache.Refresh fetches, persists, and notifies OnChange
+func TestCacheRefresh(t *testing.T) {
+	withFakeRegistry(t, fakeProvider{name: "fake", ip: net.ParseIP("203.0.113.5")})
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	c, err := NewCache(path)
+	if err != nil {
+		t.Fatalf("NewCache: %s", err)
+	}
+
+	var notified bool
+	var gotOld, gotNew net.IP
+	c.OnChange(func(old, new net.IP) {
+		notified = true
+		gotOld, gotNew = old, new
+	})
+
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %s", err)
+	}
+
+	if c.IP() == nil {
+		t.Fatal("expected a non-nil IP after Refresh")
+	}
+
+	if !notified {
+		t.Error("expected OnChange to fire on first Refresh")
+	}
+	if gotOld != nil {
+		t.Errorf("expected nil old IP on first Refresh, got %s", gotOld)
+	}
+	if !gotNew.Equal(c.IP()) {
+		t.Errorf("expected new IP %s, got %s", c.IP(), gotNew)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected cache file at %s: %s", path, err)
+	}
+
+	// A second Refresh with an unchanged address must not notify again.
+	notified = false
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %s", err)
+	}
+	if notified {
+		t.Error("expected no OnChange call when the IP hasn't changed")
+	}
+}
+
+// Test Cache.Start runs Refresh on its own and reports each attempt via onRefresh
+func TestCacheStart(t *testing.T) {
+	withFakeRegistry(t, fakeProvider{name: "fake", ip: net.ParseIP("203.0.113.5")})
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+	c, err := NewCache(path)
+	if err != nil {
+		t.Fatalf("NewCache: %s", err)
+	}
+
+	var mu sync.Mutex
+	var calls int
+	var lastErr error
+	stop := c.Start(time.Hour, func(d time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		lastErr = err
+	})
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := calls
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected onRefresh to be called at least once")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if lastErr != nil {
+		t.Errorf("unexpected refresh error: %s", lastErr)
+	}
+	if c.IP() == nil {
+		t.Error("expected a non-nil IP after Start's initial Refresh")
+	}
+}
@@ -0,0 +1,101 @@
+package whatsmyip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// familyClient returns an *http.Client whose dialer is pinned to network
+// ("tcp4" or "tcp6"), forcing address-family resolution the same way Go's
+// net package does when a caller asks specifically for one family.
+func familyClient(network string) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// isV4 reports whether ip is an IPv4 address.
+func isV4(ip net.IP) bool { return ip != nil && ip.To4() != nil }
+
+// isV6 reports whether ip is an IPv6 address that is not also representable as IPv4.
+func isV6(ip net.IP) bool { return ip != nil && ip.To4() == nil && ip.To16() != nil }
+
+// GetV4 fetches the external IPv4 address of the machine. Like Get, it
+// queries a small, scheduler-chosen subset of providers rather than every
+// registered one, over a dialer pinned to "tcp4", and discards any response
+// that isn't a valid IPv4 address, protecting against providers that may
+// answer with either family.
+func GetV4() (ip net.IP, source string, err error) {
+	providers := defaultScheduler.selectProviders(defaultRegistry.Get(), defaultScheduleCount)
+	return fetchFirstMatching(context.Background(), providers, familyClient("tcp4"), isV4)
+}
+
+// GetV6 fetches the external IPv6 address of the machine. Like Get, it
+// queries a small, scheduler-chosen subset of providers rather than every
+// registered one, over a dialer pinned to "tcp6", and discards any response
+// that isn't a valid IPv6 address, protecting against providers that may
+// answer with either family.
+func GetV6() (ip net.IP, source string, err error) {
+	providers := defaultScheduler.selectProviders(defaultRegistry.Get(), defaultScheduleCount)
+	return fetchFirstMatching(context.Background(), providers, familyClient("tcp6"), isV6)
+}
+
+// DualStackResult holds the outcome of GetBoth: the machine's external IPv4
+// and/or IPv6 address, and which Provider supplied each. A nil V4 or V6
+// means that family could not be resolved (e.g. the machine has no IPv6
+// connectivity). Sources is keyed by "v4" and "v6".
+type DualStackResult struct {
+	V4      net.IP
+	V6      net.IP
+	Sources map[string]string
+}
+
+// GetBoth fetches the machine's external IPv4 and IPv6 addresses
+// concurrently, letting callers detect dual-stack environments. It only
+// returns an error if neither family could be resolved; a single-stack
+// machine resolving just one family is not an error.
+func GetBoth() (DualStackResult, error) {
+	type outcome struct {
+		ip     net.IP
+		source string
+		err    error
+	}
+
+	v4ch := make(chan outcome, 1)
+	v6ch := make(chan outcome, 1)
+
+	go func() {
+		ip, source, err := GetV4()
+		v4ch <- outcome{ip, source, err}
+	}()
+	go func() {
+		ip, source, err := GetV6()
+		v6ch <- outcome{ip, source, err}
+	}()
+
+	v4, v6 := <-v4ch, <-v6ch
+
+	res := DualStackResult{Sources: make(map[string]string)}
+	if v4.err == nil {
+		res.V4 = v4.ip
+		res.Sources["v4"] = v4.source
+	}
+	if v6.err == nil {
+		res.V6 = v6.ip
+		res.Sources["v6"] = v6.source
+	}
+
+	if v4.err != nil && v6.err != nil {
+		return res, fmt.Errorf("dual-stack resolution failed: ipv4: %w, ipv6: %w", v4.err, v6.err)
+	}
+	return res, nil
+}
@@ -0,0 +1,42 @@
+package whatsmyip
+
+import (
+	"net"
+	"testing"
+)
+
+// Test GetV4 returns a valid IPv4 address
+func TestGetV4(t *testing.T) {
+	withFakeRegistry(t, fakeProvider{name: "fake-v4", ip: net.ParseIP("203.0.113.5")})
+
+	ip, source, err := GetV4()
+	if err != nil {
+		t.Fatalf("error: %s", err)
+	}
+
+	if !isV4(ip) {
+		t.Errorf("expected an IPv4 address, got %s", ip)
+	}
+
+	if source == "" {
+		t.Errorf("expected a non-empty source")
+	}
+}
+
+// Test GetBoth reports at least the IPv4 family when only an IPv4 provider is available
+func TestGetBoth(t *testing.T) {
+	withFakeRegistry(t, fakeProvider{name: "fake-v4", ip: net.ParseIP("203.0.113.5")})
+
+	res, err := GetBoth()
+	if err != nil {
+		t.Fatalf("error: %s", err)
+	}
+
+	if res.V4 == nil {
+		t.Errorf("expected a non-nil V4 address")
+	}
+
+	if res.Sources["v4"] == "" {
+		t.Errorf("expected a source recorded for v4")
+	}
+}
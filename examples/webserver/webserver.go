@@ -1,59 +1,57 @@
-// Fetches the IP once every 24 Hours
-// The machine current ip is returned at http://localhost:8080/ip
+// Fetches the IP once every 24 Hours, persisting it to disk via whatsmyip.Cache.
+// The machine's current IP is returned at http://localhost:8080/ip
+// Prometheus metrics are exposed at http://localhost:8080/metrics
 package main
 
 import (
 	"fmt"
+	"net"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/jipaix/whatsmyip"
+	"github.com/jipaix/whatsmyip/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-type IPCache struct {
-	mu        sync.RWMutex
-	ip        string
-	lastFetch time.Time
-}
+func main() {
+	prometheus.MustRegister(metrics.Collectors()...)
 
-func (c *IPCache) updateIP() {
-	for {
-		ip, _, err := whatsmyip.Get()
-		if err != nil {
-			fmt.Printf("Error fetching IP: %v\n", err)
-		} else {
-			c.mu.Lock()
-			c.ip = ip
-			c.lastFetch = time.Now()
-			c.mu.Unlock()
-			fmt.Printf("IP updated: %s at %s\n", ip, c.lastFetch.Format(time.RFC3339))
-		}
-		time.Sleep(24 * time.Hour)
+	cache, err := whatsmyip.NewCache("")
+	if err != nil {
+		fmt.Printf("Error creating cache: %v\n", err)
+		return
 	}
-}
-
-func (c *IPCache) getIP() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.ip
-}
 
-func main() {
-	cache := &IPCache{}
+	cache.OnChange(func(old, new net.IP) {
+		metrics.IPChangesTotal.Inc()
+		fmt.Printf("IP changed: %s -> %s\n", old, new)
+	})
 
-	// Start the IP updating goroutine
-	go cache.updateIP()
+	// Start the IP updating loop, reporting every attempt to Prometheus so
+	// /metrics reflects real fetch durations, attempt counts, and the
+	// current IP as they happen rather than staying at zero.
+	stop := cache.Start(24*time.Hour, func(d time.Duration, err error) {
+		metrics.ObserveFetch(cache.Source(), d, err)
+		if err != nil {
+			fmt.Printf("Error refreshing IP: %v\n", err)
+			return
+		}
+		metrics.SetCurrentIP(cache.IP().String(), cache.Source())
+	})
+	defer stop()
 
 	// Create a web server
 	http.HandleFunc("/ip", func(w http.ResponseWriter, r *http.Request) {
-		ip := cache.getIP()
-		if ip == "" {
+		ip := cache.IP()
+		if ip == nil {
 			http.Error(w, "IP not available yet", http.StatusServiceUnavailable)
 			return
 		}
 		fmt.Fprintf(w, "Current IP: %s\n", ip)
 	})
+	http.Handle("/metrics", promhttp.Handler())
 
 	fmt.Println("Starting server on :8080")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
@@ -0,0 +1,46 @@
+package whatsmyip
+
+import "testing"
+
+// Test getIP against the response shapes real providers are known to send,
+// plus the failure modes that used to trip up the old implementation.
+func TestGetIP(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want string // expected net.IP.String(), empty means an error is expected
+	}{
+		{"plain ipv4", "172.201.20.34", "172.201.20.34"},
+		{"plain ipv4 trailing newline", "172.201.20.34\n", "172.201.20.34"},
+		{"ip field", "ip=172.201.20.34", "172.201.20.34"},
+		{"ip field uppercase key", "IP=172.201.20.34", "172.201.20.34"},
+		{"cloudflare trace", "fl=1f1\nh=example.com\nip=203.0.113.9\nts=123\n", "203.0.113.9"},
+		{"plain ipv6", "2001:db8::1", "2001:db8::1"},
+		{"ip field mixed case value", "ip=2001:DB8::1", "2001:db8::1"},
+		{"html error page", "<html><body>502 Bad Gateway</body></html>", ""},
+		{"empty body", "   \n  ", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ip, err := getIP(tc.body)
+
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got IP %s", ip)
+				}
+				if _, ok := err.(*ErrNoIPFound); !ok {
+					t.Fatalf("expected *ErrNoIPFound, got %T: %v", err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ip.String() != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, ip)
+			}
+		})
+	}
+}
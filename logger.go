@@ -0,0 +1,87 @@
+package whatsmyip
+
+import (
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	l "github.com/charmbracelet/log"
+)
+
+// Logger is the logging interface used throughout this package. Its method
+// signatures match charmbracelet/log's Logger, so that type satisfies this
+// interface directly; adapting slog, zap, or zerolog just means writing a
+// small wrapper with these four methods.
+type Logger interface {
+	Debug(msg any, keyvals ...any)
+	Info(msg any, keyvals ...any)
+	Warn(msg any, keyvals ...any)
+	Error(msg any, keyvals ...any)
+}
+
+// log is the package-level Logger instance used for debug information and
+// errors throughout this package. It defaults to a charmbracelet/log logger
+// configured by setupLogger; call SetLogger to replace it.
+var log Logger = setupLogger()
+
+// SetLogger replaces the package-level Logger used throughout this package.
+// It lets callers integrate with slog, zap, zerolog, or any other logging
+// library without pulling in charmbracelet/log.
+func SetLogger(logger Logger) {
+	log = logger
+}
+
+// setupLogger initializes and returns a configured logger based on the APP_ENV environment variable.
+//
+// The function sets the log level according to the following APP_ENV values:
+//   - "local", "dev", "development": Debug level
+//   - "test", "staging": Info level
+//   - "prod", "production": Maximum level (effectively disabling logging)
+//   - If APP_ENV is not set: Info level
+//   - Any other value: Maximum level
+//
+// The logger is configured with the following options:
+//   - Output to stderr
+//   - Timestamp reporting enabled
+//   - Caller reporting disabled
+//   - Time format set to time.DateTime
+//   - Prefix set to "ðŸŒ "
+//
+// Returns:
+//   - *github.com/charmbracelet/log.Logger: A configured logger instance
+func setupLogger() *l.Logger {
+	env, ok := os.LookupEnv("APP_ENV")
+	var lvl l.Level
+	if !ok {
+		lvl = l.InfoLevel
+	} else {
+		// Set log level based on APP_ENV
+		switch strings.ToLower(env) {
+		case "local":
+			lvl = l.DebugLevel
+		case "dev":
+			lvl = l.DebugLevel
+		case "development":
+			lvl = l.DebugLevel
+		case "prod":
+			lvl = math.MaxInt32 // Effectively disable logging
+		case "production":
+			lvl = math.MaxInt32 // Effectively disable logging
+		case "test":
+			lvl = l.InfoLevel
+		case "staging":
+			lvl = l.InfoLevel
+		default:
+			lvl = math.MaxInt32 // Effectively disable logging
+		}
+	}
+
+	return l.NewWithOptions(os.Stderr, l.Options{
+		ReportTimestamp: true,
+		ReportCaller:    false,
+		TimeFormat:      time.DateTime,
+		Level:           lvl,
+		Prefix:          "ðŸŒ ",
+	})
+}
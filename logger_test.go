@@ -0,0 +1,35 @@
+package whatsmyip
+
+import (
+	"net"
+	"testing"
+)
+
+// stubLogger is a minimal Logger used to verify SetLogger takes effect.
+type stubLogger struct {
+	debugCalls int
+}
+
+func (s *stubLogger) Debug(msg any, keyvals ...any) { s.debugCalls++ }
+func (s *stubLogger) Info(msg any, keyvals ...any)  {}
+func (s *stubLogger) Warn(msg any, keyvals ...any)  {}
+func (s *stubLogger) Error(msg any, keyvals ...any) {}
+
+// Test that SetLogger replaces the package-level logger used by Get
+func TestSetLogger(t *testing.T) {
+	withFakeRegistry(t, fakeProvider{name: "fake", ip: net.ParseIP("203.0.113.5")})
+
+	original := log
+	defer SetLogger(original)
+
+	stub := &stubLogger{}
+	SetLogger(stub)
+
+	if _, _, err := Get(); err != nil {
+		t.Fatalf("error: %s", err)
+	}
+
+	if stub.debugCalls == 0 {
+		t.Error("expected the custom Logger to receive at least one Debug call")
+	}
+}
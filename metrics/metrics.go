@@ -0,0 +1,84 @@
+// Package metrics provides Prometheus collectors for observing a
+// whatsmyip-based application: how long each provider takes to answer, how
+// often providers succeed or fail, what the currently known external IP
+// address is, and how often that address changes.
+//
+// It is independent of the whatsmyip package itself, so importing it (and
+// its prometheus dependency) is opt-in. A typical integration registers the
+// collectors once and updates them around calls to whatsmyip.Get or a
+// whatsmyip.Cache's OnChange hook:
+//
+//	prometheus.MustRegister(metrics.Collectors()...)
+//
+//	start := time.Now()
+//	ip, source, err := whatsmyip.Get()
+//	metrics.ObserveFetch(source, time.Since(start), err)
+//	if err == nil {
+//		metrics.SetCurrentIP(ip.String(), source)
+//	}
+//
+//	cache.OnChange(func(old, new net.IP) {
+//		metrics.IPChangesTotal.Inc()
+//	})
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FetchDuration observes how long each provider takes to resolve an IP
+// address, labeled by provider name.
+var FetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "whatsmyip_fetch_duration_seconds",
+	Help:    "Time taken by each provider to resolve the external IP address.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"provider"})
+
+// FetchTotal counts fetch attempts, labeled by provider name and result
+// ("success" or "failure").
+var FetchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "whatsmyip_fetch_total",
+	Help: "Total number of fetch attempts per provider, labeled by result.",
+}, []string{"provider", "result"})
+
+// CurrentIPInfo is always 1 for the currently known external IP address and
+// its source; its labels carry the information. Use SetCurrentIP to update
+// it, which clears stale label combinations left by a previous address.
+var CurrentIPInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "whatsmyip_current_ip_info",
+	Help: "Always 1; labels identify the currently known external IP address and its source.",
+}, []string{"ip", "source"})
+
+// IPChangesTotal counts how many times the observed external IP address has
+// changed, e.g. as reported by a whatsmyip.Cache's OnChange callback.
+var IPChangesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "whatsmyip_ip_changes_total",
+	Help: "Total number of times the observed external IP address has changed.",
+})
+
+// Collectors returns every collector exposed by this package, for
+// convenient one-line registration: prometheus.MustRegister(metrics.Collectors()...).
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{FetchDuration, FetchTotal, CurrentIPInfo, IPChangesTotal}
+}
+
+// ObserveFetch records FetchDuration and FetchTotal for a single fetch
+// attempt made by provider, which failed if err is non-nil.
+func ObserveFetch(provider string, duration time.Duration, err error) {
+	FetchDuration.WithLabelValues(provider).Observe(duration.Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	FetchTotal.WithLabelValues(provider, result).Inc()
+}
+
+// SetCurrentIP updates CurrentIPInfo to reflect ip/source, clearing any
+// previously reported address so only the current one reads 1.
+func SetCurrentIP(ip, source string) {
+	CurrentIPInfo.Reset()
+	CurrentIPInfo.WithLabelValues(ip, source).Set(1)
+}
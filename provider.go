@@ -0,0 +1,125 @@
+package whatsmyip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Provider is implemented by anything capable of determining the caller's
+// external IP address. A Provider may talk HTTP, DNS, STUN, or anything
+// else — Fetch is given an *http.Client for convenience but implementations
+// that don't need one (DNS, STUN) are free to ignore it.
+type Provider interface {
+	// Name returns a short, stable, human-readable identifier for the
+	// provider (e.g. "ipify", "opendns-dns", "google-stun"). Names are
+	// used as the "source" reported by Get and friends, and as the key
+	// passed to WithNames when selecting a subset of providers.
+	Name() string
+
+	// Fetch resolves the caller's external IP address. It must respect
+	// ctx cancellation so callers can race multiple providers and abandon
+	// the losers.
+	Fetch(ctx context.Context, client *http.Client) (net.IP, error)
+}
+
+// HTTPStatusError is returned by HTTP-based Providers when the server
+// responds with a non-2xx status, so callers such as the rate-limit-aware
+// scheduler can distinguish a 429/5xx from a transport or parse failure.
+type HTTPStatusError struct {
+	Provider   string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d", e.Provider, e.StatusCode)
+}
+
+// Registry holds a set of Providers that can be queried together or
+// filtered down to a subset via Get.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register adds p to the registry, overwriting any existing provider with
+// the same Name.
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.Name()] = p
+}
+
+// Providers returns every registered Provider. The order is unspecified.
+func (r *Registry) Providers() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Provider, 0, len(r.providers))
+	for _, p := range r.providers {
+		out = append(out, p)
+	}
+	return out
+}
+
+// Option narrows the set of Providers returned by Registry.Get.
+type Option func(*getOptions)
+
+type getOptions struct {
+	names []string
+}
+
+// WithNames restricts selection to Providers whose Name is in names.
+// Unknown names are silently ignored.
+func WithNames(names ...string) Option {
+	return func(o *getOptions) { o.names = names }
+}
+
+// Get returns the Providers selected by opts, or every registered Provider
+// if no Option is given.
+func (r *Registry) Get(opts ...Option) []Provider {
+	var o getOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(o.names) == 0 {
+		out := make([]Provider, 0, len(r.providers))
+		for _, p := range r.providers {
+			out = append(out, p)
+		}
+		return out
+	}
+
+	out := make([]Provider, 0, len(o.names))
+	for _, name := range o.names {
+		if p, ok := r.providers[name]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// defaultRegistry holds the built-in Providers shipped by this package.
+// It is populated by init functions in providers_*.go.
+var defaultRegistry = NewRegistry()
+
+// Register adds p to the package's default Registry.
+func Register(p Provider) {
+	defaultRegistry.Register(p)
+}
+
+// Providers returns every Provider registered on the package's default
+// Registry.
+func Providers() []Provider {
+	return defaultRegistry.Providers()
+}
@@ -0,0 +1,71 @@
+package whatsmyip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// dnsProvider resolves the caller's external IP address by sending a
+// single query to a fixed, well-known authoritative server — the DNS
+// equivalent of `dig +short myip.opendns.com @resolver1.opendns.com`.
+// It ignores the *http.Client given to Fetch; DNS providers dial the
+// resolver directly over UDP.
+type dnsProvider struct {
+	name   string
+	server string // "host:port" of the authoritative resolver to query
+	qname  string
+	qtype  uint16
+	qclass uint16
+}
+
+// NewDNSProvider returns a Provider that queries server directly for
+// qname/qtype/qclass and extracts an IP address from the answer. A record
+// answers are read from the A/AAAA record itself; TXT answers are parsed
+// as a string containing the IP address.
+func NewDNSProvider(name, server, qname string, qtype, qclass uint16) Provider {
+	return &dnsProvider{name: name, server: server, qname: qname, qtype: qtype, qclass: qclass}
+}
+
+func (p *dnsProvider) Name() string { return p.name }
+
+func (p *dnsProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(p.qname), p.qtype)
+	m.Question[0].Qclass = p.qclass
+
+	c := new(dns.Client)
+	in, _, err := c.ExchangeContext(ctx, m, p.server)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Answer) == 0 {
+		return nil, fmt.Errorf("%s: no answer from %s", p.name, p.server)
+	}
+
+	for _, rr := range in.Answer {
+		switch rr := rr.(type) {
+		case *dns.A:
+			return rr.A, nil
+		case *dns.AAAA:
+			return rr.AAAA, nil
+		case *dns.TXT:
+			for _, s := range rr.Txt {
+				if ip := net.ParseIP(strings.Trim(s, `"`)); ip != nil {
+					return ip, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s: no IP address in answer from %s", p.name, p.server)
+}
+
+func init() {
+	Register(NewDNSProvider("opendns-dns", "resolver1.opendns.com:53", "myip.opendns.com", dns.TypeA, dns.ClassINET))
+	Register(NewDNSProvider("google-dns", "ns1.google.com:53", "o-o.myaddr.l.google.com", dns.TypeTXT, dns.ClassINET))
+	Register(NewDNSProvider("cloudflare-dns", "1.1.1.1:53", "whoami.cloudflare", dns.TypeTXT, dns.ClassCHAOS))
+}
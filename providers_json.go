@@ -0,0 +1,70 @@
+package whatsmyip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// jsonProvider fetches an IP address from an HTTP endpoint that responds
+// with a JSON object containing the address under a single top-level
+// string field (e.g. {"ip": "172.201.20.34"}).
+type jsonProvider struct {
+	name  string
+	url   string
+	field string
+}
+
+// NewJSONProvider returns a Provider that issues an HTTP GET to url and
+// extracts the external IP address from the string field named field in
+// the JSON response body.
+func NewJSONProvider(name, url, field string) Provider {
+	return &jsonProvider{name: name, url: url, field: field}
+}
+
+func (p *jsonProvider) Name() string { return p.name }
+
+func (p *jsonProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPStatusError{Provider: p.name, StatusCode: resp.StatusCode}
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	raw, ok := body[p.field]
+	if !ok {
+		return nil, fmt.Errorf("%s: response has no %q field", p.name, p.field)
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: %q field is not a string", p.name, p.field)
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%s: %q is not a valid IP address", p.name, s)
+	}
+	return ip, nil
+}
+
+func init() {
+	Register(NewJSONProvider("ipinfo-json", "https://ipinfo.io/json", "ip"))
+	Register(NewJSONProvider("ifconfigco-json", "https://ifconfig.co/json", "ip"))
+}
@@ -0,0 +1,73 @@
+package whatsmyip
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+)
+
+// plainTextProvider fetches an IP address from an HTTP endpoint that
+// responds with a plain-text body (optionally containing "ip=" fields,
+// see getIP).
+type plainTextProvider struct {
+	name string
+	url  string
+}
+
+// NewPlainTextProvider returns a Provider that issues an HTTP GET to url
+// and extracts the external IP address from the plain-text response body
+// using getIP.
+func NewPlainTextProvider(name, url string) Provider {
+	return &plainTextProvider{name: name, url: url}
+}
+
+func (p *plainTextProvider) Name() string { return p.name }
+
+func (p *plainTextProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &HTTPStatusError{Provider: p.name, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return getIP(string(body))
+}
+
+// plainTextEndpoints lists the built-in HTTP endpoints that respond with a
+// plain-text body containing the caller's IP address. Each is registered
+// on the default Registry as a plainTextProvider keyed by its hostname.
+var plainTextEndpoints = map[string]string{
+	"cloudflare":   "https://cloudflare.com/cdn-cgi/trace",
+	"amazonaws":    "https://checkip.amazonaws.com",
+	"ipify":        "https://api.ipify.org",
+	"icanhazip":    "https://icanhazip.com",
+	"myexternalip": "https://myexternalip.com/raw",
+	"ipinfo":       "https://ipinfo.io/ip",
+	"ipecho":       "https://ipecho.net/plain",
+	"ifconfigme":   "https://ifconfig.me/ip",
+	"identme":      "https://ident.me",
+	"akamai":       "https://whatismyip.akamai.com",
+	"wgetip":       "https://wgetip.com",
+	"tyk":          "https://ip.tyk.nu",
+}
+
+func init() {
+	for name, url := range plainTextEndpoints {
+		Register(NewPlainTextProvider(name, url))
+	}
+}
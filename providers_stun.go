@@ -0,0 +1,153 @@
+package whatsmyip
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// stunProvider resolves the caller's external IP address by sending an
+// RFC 5389 STUN binding request to server and reading the XOR-MAPPED-ADDRESS
+// attribute from the response. It ignores the *http.Client given to Fetch;
+// STUN is a UDP protocol.
+type stunProvider struct {
+	name   string
+	server string // "host:port" of the STUN server to query
+}
+
+// NewSTUNProvider returns a Provider that sends a STUN binding request to
+// server and extracts the caller's external IP address from the
+// XOR-MAPPED-ADDRESS attribute of the response.
+func NewSTUNProvider(name, server string) Provider {
+	return &stunProvider{name: name, server: server}
+}
+
+func (p *stunProvider) Name() string { return p.name }
+
+const (
+	stunBindingRequest    = 0x0001
+	stunAttrXorMappedAddr = 0x0020
+	stunMagicCookie       = 0x2112A442
+	stunFamilyIPv4        = 0x01
+	stunFamilyIPv6        = 0x02
+	stunHeaderLen         = 20
+)
+
+func (p *stunProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	conn, err := net.Dial("udp", p.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	txID := make([]byte, 12)
+	if _, err := rand.Read(txID); err != nil {
+		return nil, err
+	}
+
+	req := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(req[2:4], 0) // message length, no attributes
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	copy(req[8:20], txID)
+
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp = resp[:n]
+
+	return parseXorMappedAddress(resp, txID)
+}
+
+// parseXorMappedAddress walks a STUN response message looking for an
+// XOR-MAPPED-ADDRESS attribute and returns the IP address it encodes. It
+// first verifies that the response carries our own magic cookie and
+// transaction ID (wantTxID), rejecting any datagram that doesn't — without
+// this, an off-path attacker spoofing UDP packets to the client could feed
+// it an arbitrary "external" IP address.
+func parseXorMappedAddress(msg []byte, wantTxID []byte) (net.IP, error) {
+	if len(msg) < stunHeaderLen {
+		return nil, fmt.Errorf("stun: response too short")
+	}
+
+	if binary.BigEndian.Uint32(msg[4:8]) != stunMagicCookie {
+		return nil, fmt.Errorf("stun: response has wrong magic cookie")
+	}
+	if !bytes.Equal(msg[8:20], wantTxID) {
+		return nil, fmt.Errorf("stun: response has mismatched transaction ID")
+	}
+
+	attrs := msg[stunHeaderLen:]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+attrLen > len(attrs) {
+			break
+		}
+		val := attrs[4 : 4+attrLen]
+
+		if attrType == stunAttrXorMappedAddr {
+			if len(val) < 4 {
+				return nil, fmt.Errorf("stun: malformed XOR-MAPPED-ADDRESS")
+			}
+			family := val[1]
+			xport := binary.BigEndian.Uint16(val[2:4]) ^ uint16(stunMagicCookie>>16)
+			_ = xport // port is not part of the IP we report
+
+			switch family {
+			case stunFamilyIPv4:
+				if len(val) < 8 {
+					return nil, fmt.Errorf("stun: malformed IPv4 XOR-MAPPED-ADDRESS")
+				}
+				var cookie [4]byte
+				binary.BigEndian.PutUint32(cookie[:], stunMagicCookie)
+				ip := make(net.IP, 4)
+				for i := range ip {
+					ip[i] = val[4+i] ^ cookie[i]
+				}
+				return ip, nil
+			case stunFamilyIPv6:
+				if len(val) < 20 {
+					return nil, fmt.Errorf("stun: malformed IPv6 XOR-MAPPED-ADDRESS")
+				}
+				var cookie [16]byte
+				binary.BigEndian.PutUint32(cookie[0:4], stunMagicCookie)
+				copy(cookie[4:16], msg[8:20]) // transaction ID
+				ip := make(net.IP, 16)
+				for i := range ip {
+					ip[i] = val[4+i] ^ cookie[i]
+				}
+				return ip, nil
+			default:
+				return nil, fmt.Errorf("stun: unknown address family %d", family)
+			}
+		}
+
+		// Attributes are padded to a multiple of 4 bytes.
+		padded := (attrLen + 3) &^ 3
+		attrs = attrs[4+padded:]
+	}
+
+	return nil, fmt.Errorf("stun: no XOR-MAPPED-ADDRESS in response")
+}
+
+func init() {
+	Register(NewSTUNProvider("google-stun", "stun.l.google.com:19302"))
+}
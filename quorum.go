@@ -0,0 +1,117 @@
+package whatsmyip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// ErrNoQuorum is returned by GetQuorum when fewer than the requested number
+// of providers agreed on an IP address. Use errors.Is to detect it; the
+// disagreeing responses are available on the accompanying *NoQuorumError
+// via errors.As.
+var ErrNoQuorum = errors.New("whatsmyip: no quorum reached")
+
+// NoQuorumError is returned by GetQuorum when no candidate IP address was
+// reported by enough providers. It wraps ErrNoQuorum and carries every
+// provider's response so callers can inspect or log the disagreement.
+type NoQuorumError struct {
+	Results []ProviderResult
+}
+
+func (e *NoQuorumError) Error() string {
+	return fmt.Sprintf("%s (%d providers queried)", ErrNoQuorum, len(e.Results))
+}
+
+func (e *NoQuorumError) Unwrap() error { return ErrNoQuorum }
+
+// ProviderResult is one Provider's response as observed by GetQuorum,
+// including how long it took to answer.
+type ProviderResult struct {
+	Provider string
+	IP       net.IP
+	Err      error
+	Latency  time.Duration
+}
+
+// QuorumResult is the outcome of a successful GetQuorum call: the agreed-
+// upon IP address, plus every individual provider response that went into
+// that decision.
+type QuorumResult struct {
+	IP      net.IP
+	Results []ProviderResult
+}
+
+// GetQuorum queries n randomly chosen providers from the default Registry
+// concurrently and returns the IP address reported by at least agree of
+// them, skipping any provider whose circuit is currently open (see Stats).
+// This defends against a single lying or hijacked provider (DNS hijack,
+// MITM'd captive portal, compromised endpoint) that Get alone would trust
+// unconditionally, since Get returns whichever answer arrives first.
+//
+// If no IP address is reported by at least agree providers, GetQuorum
+// returns a *NoQuorumError (wrapping ErrNoQuorum) carrying every provider's
+// response, and logs a warning describing the disagreement.
+//
+// n and agree must both be positive; GetQuorum returns an error otherwise
+// rather than querying nothing and reporting a spurious nil-error result.
+func GetQuorum(ctx context.Context, n, agree int) (QuorumResult, error) {
+	if n <= 0 || agree <= 0 {
+		return QuorumResult{}, fmt.Errorf("whatsmyip: GetQuorum: n and agree must be positive (got n=%d, agree=%d)", n, agree)
+	}
+	if agree > n {
+		agree = n
+	}
+
+	candidates := defaultRegistry.Get()
+	providers := make([]Provider, 0, len(candidates))
+	for _, p := range candidates {
+		if !defaultScheduler.isOpen(p.Name()) {
+			providers = append(providers, p)
+		}
+	}
+
+	rand.Shuffle(len(providers), func(i, j int) { providers[i], providers[j] = providers[j], providers[i] })
+	if n < len(providers) {
+		providers = providers[:n]
+	}
+
+	results := make([]ProviderResult, len(providers))
+	resCh := make(chan ProviderResult, len(providers))
+
+	for _, p := range providers {
+		go func(p Provider) {
+			start := time.Now()
+			ip, err := p.Fetch(ctx, httpClient)
+			resCh <- ProviderResult{Provider: p.Name(), IP: ip, Err: err, Latency: time.Since(start)}
+		}(p)
+	}
+	for i := range results {
+		results[i] = <-resCh
+	}
+
+	votes := make(map[string]int)
+	for _, r := range results {
+		if r.Err == nil && r.IP != nil {
+			votes[r.IP.String()]++
+		}
+	}
+
+	var winner string
+	var winnerVotes int
+	for ip, count := range votes {
+		if count > winnerVotes {
+			winner, winnerVotes = ip, count
+		}
+	}
+
+	if winnerVotes >= agree {
+		return QuorumResult{IP: net.ParseIP(winner), Results: results}, nil
+	}
+
+	log.Warn("quorum disagreement", "wanted", agree, "best", winnerVotes, "distinct_answers", len(votes), "queried", len(results))
+	return QuorumResult{Results: results}, &NoQuorumError{Results: results}
+}
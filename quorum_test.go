@@ -0,0 +1,81 @@
+package whatsmyip
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// Test GetQuorum reaches agreement when every queried provider is honest
+func TestGetQuorum(t *testing.T) {
+	withFakeRegistry(t,
+		fakeProvider{name: "fake-a", ip: net.ParseIP("203.0.113.5")},
+		fakeProvider{name: "fake-b", ip: net.ParseIP("203.0.113.5")},
+		fakeProvider{name: "fake-c", ip: net.ParseIP("203.0.113.5")},
+	)
+
+	res, err := GetQuorum(context.Background(), 3, 2)
+	if err != nil {
+		t.Fatalf("error: %s", err)
+	}
+
+	if res.IP == nil {
+		t.Error("expected a non-nil quorum IP")
+	}
+
+	if len(res.Results) == 0 {
+		t.Error("expected per-provider results to be populated")
+	}
+}
+
+// Test GetQuorum fails with ErrNoQuorum when agreement is impossible to reach
+func TestGetQuorumNoQuorum(t *testing.T) {
+	withFakeRegistry(t,
+		fakeProvider{name: "fake-a", ip: net.ParseIP("203.0.113.5")},
+		fakeProvider{name: "fake-b", ip: net.ParseIP("203.0.113.9")},
+		fakeProvider{name: "fake-c", ip: net.ParseIP("203.0.113.77")},
+	)
+
+	_, err := GetQuorum(context.Background(), 3, 99)
+
+	var nqErr *NoQuorumError
+	if !errors.As(err, &nqErr) {
+		t.Fatalf("expected a *NoQuorumError, got %v (%T)", err, err)
+	}
+
+	if !errors.Is(err, ErrNoQuorum) {
+		t.Error("expected errors.Is(err, ErrNoQuorum) to hold")
+	}
+
+	if len(nqErr.Results) == 0 {
+		t.Error("expected disagreeing results to be attached")
+	}
+}
+
+// Test GetQuorum rejects non-positive n/agree instead of panicking or
+// reporting a spurious result
+func TestGetQuorumInvalidArgs(t *testing.T) {
+	cases := []struct {
+		name  string
+		n     int
+		agree int
+	}{
+		{"negative n", -1, 2},
+		{"zero n", 0, 2},
+		{"zero agree", 3, 0},
+		{"negative agree", 3, -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := GetQuorum(context.Background(), tc.n, tc.agree)
+			if err == nil {
+				t.Fatalf("expected an error, got result %+v", res)
+			}
+			if res.IP != nil {
+				t.Errorf("expected a zero-value QuorumResult, got IP %s", res.IP)
+			}
+		})
+	}
+}
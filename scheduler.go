@@ -0,0 +1,203 @@
+package whatsmyip
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultScheduleCount is how many providers Get queries per call by
+// default, instead of blasting every registered provider as earlier
+// versions of this package did.
+const defaultScheduleCount = 3
+
+// circuitBreakerThreshold is the number of consecutive failures that trips
+// a provider's circuit open, independent of status code.
+const circuitBreakerThreshold = 3
+
+// maxBackoff caps how long a tripped circuit stays open.
+const maxBackoff = time.Hour
+
+// ProviderStats is a point-in-time snapshot of a provider's observed
+// reliability, exposed via Stats for monitoring and debugging.
+type ProviderStats struct {
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+	AvgLatency          time.Duration
+	OpenUntil           time.Time // zero value means the circuit is closed
+}
+
+// providerStats is the mutable, internal counterpart of ProviderStats.
+type providerStats struct {
+	successes           int
+	failures            int
+	consecutiveFailures int
+	avgLatency          time.Duration
+	backoff             time.Duration
+	openUntil           time.Time
+}
+
+// scheduler tracks per-provider reliability and selects a biased subset of
+// providers for each Get call, skipping any whose circuit is open.
+type scheduler struct {
+	mu    sync.RWMutex
+	stats map[string]*providerStats
+}
+
+var defaultScheduler = &scheduler{stats: make(map[string]*providerStats)}
+
+// stat returns (creating if necessary) the stats entry for name. Callers
+// must hold s.mu.
+func (s *scheduler) stat(name string) *providerStats {
+	st, ok := s.stats[name]
+	if !ok {
+		st = &providerStats{}
+		s.stats[name] = st
+	}
+	return st
+}
+
+// recordSuccess updates a provider's stats after a successful fetch,
+// closing its circuit and resetting its failure streak.
+func (s *scheduler) recordSuccess(name string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stat(name)
+	st.successes++
+	st.consecutiveFailures = 0
+	st.backoff = 0
+	st.openUntil = time.Time{}
+	if st.avgLatency == 0 {
+		st.avgLatency = latency
+	} else {
+		st.avgLatency = (st.avgLatency + latency) / 2
+	}
+}
+
+// recordFailure updates a provider's stats after a failed fetch. A 429, a
+// 5xx, or a third consecutive failure of any kind trips the circuit open
+// for an exponentially increasing backoff, capped at maxBackoff.
+func (s *scheduler) recordFailure(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.stat(name)
+	st.failures++
+	st.consecutiveFailures++
+
+	var statusErr *HTTPStatusError
+	rateLimited := errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500)
+
+	if rateLimited || st.consecutiveFailures >= circuitBreakerThreshold {
+		if st.backoff == 0 {
+			st.backoff = time.Second
+		} else {
+			st.backoff *= 2
+		}
+		if st.backoff > maxBackoff {
+			st.backoff = maxBackoff
+		}
+		st.openUntil = time.Now().Add(st.backoff)
+	}
+}
+
+// isOpen reports whether name's circuit is currently open.
+func (s *scheduler) isOpen(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.stats[name]
+	return ok && time.Now().Before(st.openUntil)
+}
+
+// weight scores a provider for weighted-random selection: higher success
+// rate and lower latency both increase the odds of being picked. Providers
+// with no history yet get a neutral weight so they still get a chance.
+func weight(st *providerStats) float64 {
+	if st == nil || (st.successes == 0 && st.failures == 0) {
+		return 1.0
+	}
+
+	total := st.successes + st.failures
+	successRate := float64(st.successes) / float64(total)
+
+	latencyFactor := 1.0
+	if st.avgLatency > 0 {
+		latencyFactor = float64(time.Second) / float64(st.avgLatency)
+	}
+
+	w := successRate * latencyFactor
+	if w <= 0 {
+		w = 0.01 // still give a failing provider a small chance to recover
+	}
+	return w
+}
+
+// selectProviders returns up to count providers from candidates, chosen by
+// weighted random sampling without replacement, skipping any whose circuit
+// is currently open.
+func (s *scheduler) selectProviders(candidates []Provider, count int) []Provider {
+	eligible := make([]Provider, 0, len(candidates))
+	weights := make([]float64, 0, len(candidates))
+
+	s.mu.RLock()
+	for _, p := range candidates {
+		st := s.stats[p.Name()]
+		if st != nil && time.Now().Before(st.openUntil) {
+			continue
+		}
+		eligible = append(eligible, p)
+		weights = append(weights, weight(st))
+	}
+	s.mu.RUnlock()
+
+	if count >= len(eligible) {
+		return eligible
+	}
+
+	selected := make([]Provider, 0, count)
+	for len(selected) < count && len(eligible) > 0 {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+
+		r := rand.Float64() * total
+		idx := len(eligible) - 1
+		for i, w := range weights {
+			r -= w
+			if r <= 0 {
+				idx = i
+				break
+			}
+		}
+
+		selected = append(selected, eligible[idx])
+		eligible = append(eligible[:idx], eligible[idx+1:]...)
+		weights = append(weights[:idx], weights[idx+1:]...)
+	}
+	return selected
+}
+
+// Stats returns a point-in-time snapshot of every provider's reliability
+// stats as tracked by the default scheduler used by Get.
+func Stats() map[string]ProviderStats {
+	defaultScheduler.mu.RLock()
+	defer defaultScheduler.mu.RUnlock()
+
+	out := make(map[string]ProviderStats, len(defaultScheduler.stats))
+	for name, st := range defaultScheduler.stats {
+		out[name] = ProviderStats{
+			Successes:           st.successes,
+			Failures:            st.failures,
+			ConsecutiveFailures: st.consecutiveFailures,
+			AvgLatency:          st.avgLatency,
+			OpenUntil:           st.openUntil,
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package whatsmyip
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var errFetchFailed = errors.New("simulated fetch failure")
+
+// Test that three consecutive failures trip a provider's circuit, and that
+// it reports closed again after a success.
+func TestSchedulerCircuitBreaker(t *testing.T) {
+	s := &scheduler{stats: make(map[string]*providerStats)}
+
+	if s.isOpen("flaky") {
+		t.Fatal("circuit should start closed")
+	}
+
+	for i := 0; i < circuitBreakerThreshold; i++ {
+		s.recordFailure("flaky", errFetchFailed)
+	}
+	if !s.isOpen("flaky") {
+		t.Error("expected circuit to open after consecutive failures")
+	}
+
+	s.recordSuccess("flaky", 10*time.Millisecond)
+	if s.isOpen("flaky") {
+		t.Error("expected circuit to close after a success")
+	}
+}
+
+// Test that a single 429 response trips the circuit immediately.
+func TestSchedulerRateLimitOpensImmediately(t *testing.T) {
+	s := &scheduler{stats: make(map[string]*providerStats)}
+
+	s.recordFailure("limited", &HTTPStatusError{Provider: "limited", StatusCode: http.StatusTooManyRequests})
+	if !s.isOpen("limited") {
+		t.Error("expected a 429 to open the circuit on the first failure")
+	}
+}
+
+// Test that selectProviders skips providers whose circuit is open.
+func TestSchedulerSelectSkipsOpenCircuits(t *testing.T) {
+	s := &scheduler{stats: make(map[string]*providerStats)}
+	for i := 0; i < circuitBreakerThreshold; i++ {
+		s.recordFailure("down", errFetchFailed)
+	}
+
+	candidates := []Provider{failingProvider{name: "down"}, failingProvider{name: "up"}}
+	selected := s.selectProviders(candidates, 2)
+
+	for _, p := range selected {
+		if p.Name() == "down" {
+			t.Error("expected provider with an open circuit to be skipped")
+		}
+	}
+}
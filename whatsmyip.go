@@ -1,249 +1,203 @@
 // Package whatsmyip provides functionality to determine the external IP address of the machine.
 //
-// This package uses multiple online services to fetch the IP address, improving reliability
-// and reducing dependency on any single service. It employs concurrent requests and returns
-// the first successful response, cancelling other ongoing requests.
-//
-// The main function of this package is Get(), which returns the external IP address.
-// The package also includes internal utilities for logging.
+// This package ships a registry of pluggable Providers (plain-text and JSON
+// HTTP endpoints, DNS-based lookups, and STUN) used to fetch the IP address,
+// improving reliability and reducing dependency on any single service. Get
+// queries a small, scheduler-chosen subset of providers concurrently and
+// returns the first successful response, cancelling the rest — see Stats
+// for the reliability data driving that choice.
+//
+// The main function of this package is Get(), which returns the external IP
+// address. The package also includes internal utilities for logging.
 package whatsmyip
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"math"
-	"math/rand/v2"
+	"net"
 	"net/http"
-	"os"
+	"regexp"
 	"strings"
 	"time"
-
-	l "github.com/charmbracelet/log"
 )
 
-// log is the package-level logger instance, configured by the setupLogger function.
-// It is used throughout the package for logging debug information and errors.
-var log = setupLogger()
-
-// urls is a list of URLs used to fetch the external IP address of the machine.
-// These URLs are expected to return a plain/text response containing the IP address.
-//
-// Expected response formats:
-//  1. Single line with IP:
-//     "172.201.20.34"
-//  2. Single or multiple lines with an "ip=" field:
-//     "ip=172.201.20.34"
-//
-// The order of URLs is randomized before use to distribute load across services.
-// This helps prevent overloading any single service with repeated requests.
-var urls = []string{
-	"https://cloudflare.com/cdn-cgi/trace",
-	"https://checkip.amazonaws.com",
-	"https://api.ipify.org",
-	"https://icanhazip.com",
-	"https://myexternalip.com/raw",
-	"https://ipinfo.io/ip",
-	"https://ipecho.net/plain",
-	"https://ifconfig.me/ip",
-	"https://ident.me",
-	"https://whatismyip.akamai.com",
-	"https://wgetip.com",
-	"https://ip.tyk.nu",
-}
+// httpClient is shared by every HTTP-based Provider queried by Get.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
 
-// Get fetches the external IP address of the machine by concurrently querying multiple URLs.
+// Get fetches the external IP address of the machine by concurrently
+// querying a small subset of registered Providers, chosen by the default
+// scheduler's weighted selection (see Stats).
 //
 // The function performs the following steps:
-// 1. Creates a cancellable context
-// 2. Shuffles the list of URLs to randomize the order of requests
-// 3. Concurrently sends HTTP GET requests to all URLs
-// 4. Returns the first successfully retrieved IP address
-// 5. Cancels all ongoing requests once a successful response is received
+// 1. Asks the scheduler to pick defaultScheduleCount providers, skipping any with an open circuit
+// 2. Concurrently queries the selected Providers
+// 3. Returns the first successfully retrieved IP address
+// 4. Cancels all ongoing requests once a successful response is received
+//
+// Unlike earlier versions of this package, Get no longer blasts every
+// registered provider on every call: doing so is wasteful and unfriendly to
+// free services. Per-provider success rate, latency, and HTTP 429/5xx
+// responses feed back into the scheduler so reliable, fast providers are
+// favored and misbehaving ones are temporarily skipped.
 //
 // If all requests fail, it returns an error.
 //
 // Return values:
-//   - ip: The retrieved external IP address (empty string if all requests fail)
-//   - url: The URL that successfully provided the IP address (empty string if all requests fail)
+//   - ip: The retrieved external IP address (nil if all requests fail)
+//   - source: The name of the Provider that supplied the IP (empty string if all requests fail)
 //   - err: Error if all requests fail, nil otherwise
 //
 // The function uses the APP_ENV environment variable to determine the log level.
 // It logs debug information for successful fetches and an error if all requests fail.
 //
 // This function is designed to be resilient, fast, and to reduce load on any single IP lookup service.
-func Get() (ip string, url string, err error) {
-	start := time.Now()
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func Get() (ip net.IP, source string, err error) {
+	return getContext(context.Background())
+}
+
+// getContext behaves like Get, but lets callers that already carry a
+// context (e.g. Cache.Refresh) bound or cancel the underlying fetches
+// instead of always racing to whatever httpClient's own timeout allows.
+func getContext(ctx context.Context) (net.IP, string, error) {
+	providers := defaultScheduler.selectProviders(defaultRegistry.Get(), defaultScheduleCount)
+	return fetchFirstMatching(ctx, providers, httpClient, anyFamily)
+}
 
-	ch := make(chan string, len(urls))
+// fetchFirst queries providers concurrently and returns the IP address and
+// Provider name of whichever responds first with a valid address. All other
+// in-flight requests are cancelled once a winner is found.
+func fetchFirst(ctx context.Context, providers []Provider) (net.IP, string, error) {
+	return fetchFirstMatching(ctx, providers, httpClient, anyFamily)
+}
 
-	// Shuffle URLs to distribute load across services
-	rand.Shuffle(len(urls), func(i, j int) { urls[i], urls[j] = urls[j], urls[i] })
+// anyFamily accepts any non-nil IP address, regardless of family.
+func anyFamily(ip net.IP) bool { return ip != nil }
+
+// fetchFirstMatching queries providers concurrently using client and returns
+// the IP address and Provider name of whichever responds first with an
+// address for which accept returns true. Every outcome is reported to the
+// default scheduler so future calls through Get, GetV4, and GetV6 can favor
+// fast, reliable providers and skip ones that are rate-limiting or down.
+// Responses that fail accept (e.g. an IPv6-only provider queried for GetV4)
+// count as a failure for scheduling purposes: the rest of the race
+// continues. Cancellation of a losing request (once a winner is found) is
+// not counted as a failure.
+func fetchFirstMatching(ctx context.Context, providers []Provider, client *http.Client, accept func(net.IP) bool) (net.IP, string, error) {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	for _, url := range urls {
-		go fetchURL(ctx, url, ch)
+	type result struct {
+		ip     net.IP
+		source string
+	}
+	ch := make(chan result, len(providers))
+
+	for _, p := range providers {
+		go func(p Provider) {
+			fetchStart := time.Now()
+			ip, err := p.Fetch(ctx, client)
+			if err == nil && !accept(ip) {
+				err = fmt.Errorf("%s: %s is not an acceptable address", p.Name(), ip)
+			}
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					defaultScheduler.recordFailure(p.Name(), err)
+				}
+				ch <- result{}
+				return
+			}
+			defaultScheduler.recordSuccess(p.Name(), time.Since(fetchStart))
+			ch <- result{ip: ip, source: p.Name()}
+		}(p)
 	}
 
-	for i := 0; i < len(urls); i++ {
-		result := <-ch
-		if result != "" {
+	for i := 0; i < len(providers); i++ {
+		r := <-ch
+		if r.ip != nil {
 			cancel() // Cancel other ongoing requests
-			log.Debug("Fetch completed", "elapsed", time.Since(start).String(), "pos", i, "url", urls[i])
-			return result, urls[i], nil
+			log.Debug("Fetch completed", "elapsed", time.Since(start).String(), "pos", i, "source", r.source)
+			return r.ip, r.source, nil
 		}
 	}
 	log.Error("All requests failed")
-	return "", "", fmt.Errorf("all requests failed")
+	return nil, "", fmt.Errorf("all requests failed")
 }
 
-// fetchURL attempts to retrieve an IP address from the specified URL.
-//
-// It takes three parameters:
-//   - ctx: A context.Context for cancellation and timeouts
-//   - url: The URL to fetch the IP address from
-//   - ch: A channel to send the result back to the caller
-//
-// The function performs an HTTP GET request to the given URL. If successful,
-// it attempts to extract an IP address from the response body using the getIP function.
-// The extracted IP is sent to the channel if successful, otherwise an empty string is sent.
-//
-// Any error during the process (request creation, HTTP request, body reading, or IP extraction)
-// results in an empty string being sent to the channel.
-//
-// This function is designed to be run as a goroutine in a concurrent fetch operation.
-func fetchURL(ctx context.Context, url string, ch chan<- string) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		ch <- ""
-		return
-	}
+// ErrNoIPFound is returned by getIP when no line of a response body could be
+// parsed as an IP address. The raw body is attached (truncated to 256
+// bytes) so callers can log or inspect what a provider actually sent —
+// useful when a misbehaving proxy returns an HTML error page instead of an
+// IP address.
+type ErrNoIPFound struct {
+	Body []byte
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		ch <- ""
-		return
-	}
-	defer resp.Body.Close()
+func (e *ErrNoIPFound) Error() string {
+	return fmt.Sprintf("no IP address found in response: %q", e.Body)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		ch <- ""
-		return
-	}
+// ipFieldRegexp matches an "ip=<value>" field on its own line, case-insensitively.
+var ipFieldRegexp = regexp.MustCompile(`(?i)^ip=(.+)$`)
 
-	ip, err := getIP(string(body))
-	if err != nil {
-		ch <- ""
-		return
-	}
+// ipv4LiteralRegexp matches an IPv4 literal anywhere in a line.
+var ipv4LiteralRegexp = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)
 
-	ch <- ip
-}
+// ipv6LiteralRegexp matches a plausible IPv6 literal anywhere in a line.
+// It is deliberately permissive; net.ParseIP does the real validation.
+var ipv6LiteralRegexp = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}\b`)
 
-// getIP extracts an IP address from a given string.
-//
-// The function handles various formats of input:
-//  1. Single-line responses: Returns the entire string as the IP.
-//  2. Multi-line responses: Searches for a line starting with "ip=".
-//  3. Special case: For a two-line response where the second line is empty,
-//     it returns either the "ip=" value or the first line.
-//
-// The function is case-insensitive, converting all input to lowercase before processing.
+// getIP extracts an IP address from a provider's response body.
 //
-// Parameters:
-//   - s: A string containing the potential IP address.
+// The body is trimmed and split into lines, and each non-empty line is
+// tried, in order, against three strategies:
+//  1. The whole line parses directly as an IP address.
+//  2. The line is an "ip=<value>" field (case-insensitive key) whose value parses as an IP address.
+//  3. The first IPv4 or IPv6 literal found anywhere in the line parses as an IP address.
 //
-// Returns:
-//   - string: The extracted IP address.
-//   - error: An error if no IP address is found or if the response is empty.
+// The first line to produce a valid address via any strategy wins. This
+// deliberately does not lowercase the body before matching, since doing so
+// would corrupt the case-sensitive zone ID of an IPv6 literal.
 //
-// Error cases:
-//   - Returns an error if the input string is empty.
-//   - Returns an error if no IP address is found in a multi-line input.
-//
-// Note: This function assumes that a single-line response always contains a valid IP address.
-// It may return unexpected results if this assumption is not met.
-func getIP(s string) (string, error) {
-	s = strings.ToLower(s) // Convert to lowercase for case-insensitive matching
-	if strings.Contains(s, "\n") {
-		lines := strings.Split(s, "\n")
-
-		if len(lines) == 0 {
-			log.Error("Empty response")
-			return "", fmt.Errorf("empty response")
+// If no line yields a valid address — including when the body is just an
+// HTML error page from a misbehaving proxy — getIP returns *ErrNoIPFound
+// with the raw body attached.
+func getIP(s string) (net.IP, error) {
+	s = strings.TrimSpace(s)
+
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
 		}
 
-		if len(lines) == 2 && lines[1] == "" {
-			if strings.HasPrefix(lines[0], "ip=") {
-				return strings.TrimPrefix(lines[0], "ip="), nil
-			} else {
-				return lines[0], nil
-			}
+		if ip := net.ParseIP(line); ip != nil {
+			return ip, nil
 		}
 
-		for _, line := range lines {
-			if strings.HasPrefix(line, "ip=") {
-				return strings.TrimPrefix(line, "ip="), nil
+		if m := ipFieldRegexp.FindStringSubmatch(line); m != nil {
+			if ip := net.ParseIP(strings.TrimSpace(m[1])); ip != nil {
+				return ip, nil
 			}
 		}
-	} else {
-		return s, nil
-	}
-	return "", fmt.Errorf("no ip address found")
-}
 
-// setupLogger initializes and returns a configured logger based on the APP_ENV environment variable.
-//
-// The function sets the log level according to the following APP_ENV values:
-//   - "local", "dev", "development": Debug level
-//   - "test", "staging": Info level
-//   - "prod", "production": Maximum level (effectively disabling logging)
-//   - If APP_ENV is not set: Info level
-//   - Any other value: Maximum level
-//
-// The logger is configured with the following options:
-//   - Output to stderr
-//   - Timestamp reporting enabled
-//   - Caller reporting disabled
-//   - Time format set to time.DateTime
-//   - Prefix set to "ðŸŒ "
-//
-// Returns:
-//   - *github.com/charmbracelet/log.Logger: A configured logger instance
-func setupLogger() *l.Logger {
-	env, ok := os.LookupEnv("APP_ENV")
-	var lvl l.Level
-	if !ok {
-		lvl = l.InfoLevel
-	} else {
-		// Set log level based on APP_ENV
-		switch strings.ToLower(env) {
-		case "local":
-			lvl = l.DebugLevel
-		case "dev":
-			lvl = l.DebugLevel
-		case "development":
-			lvl = l.DebugLevel
-		case "prod":
-			lvl = math.MaxInt32 // Effectively disable logging
-		case "production":
-			lvl = math.MaxInt32 // Effectively disable logging
-		case "test":
-			lvl = l.InfoLevel
-		case "staging":
-			lvl = l.InfoLevel
-		default:
-			lvl = math.MaxInt32 // Effectively disable logging
+		if m := ipv4LiteralRegexp.FindString(line); m != "" {
+			if ip := net.ParseIP(m); ip != nil {
+				return ip, nil
+			}
+		}
+		if m := ipv6LiteralRegexp.FindString(line); m != "" {
+			if ip := net.ParseIP(m); ip != nil {
+				return ip, nil
+			}
 		}
 	}
 
-	return l.NewWithOptions(os.Stderr, l.Options{
-		ReportTimestamp: true,
-		ReportCaller:    false,
-		TimeFormat:      time.DateTime,
-		Level:           lvl,
-		Prefix:          "ðŸŒ ",
-	})
+	body := []byte(s)
+	if len(body) > 256 {
+		body = body[:256]
+	}
+	log.Error("No IP address found in response")
+	return nil, &ErrNoIPFound{Body: body}
 }
@@ -1,7 +1,10 @@
 package whatsmyip
 
 import (
+	"context"
+	"errors"
 	"net"
+	"net/http"
 	"os/exec"
 	"runtime"
 	"slices"
@@ -41,11 +44,18 @@ func init() {
 func TestGet(t *testing.T) {
 	ip, source, err := Get()
 
-	if net.ParseIP(ip) == nil {
+	if ip == nil {
 		t.Errorf("invalid IP address: %s", ip)
 	}
 
-	if !slices.Contains(urls, source) {
+	found := false
+	for _, p := range Providers() {
+		if p.Name() == source {
+			found = true
+			break
+		}
+	}
+	if !found {
 		t.Errorf("invalid source: %s", source)
 	}
 
@@ -55,20 +65,29 @@ func TestGet(t *testing.T) {
 
 	// We're only testing this with OS w/ curl or equivalent
 	if expectedToCURL {
-		if ip != machineIP {
+		if ip.String() != machineIP {
 			t.Errorf("expected %s, got %s", machineIP, ip)
 		}
 	}
 }
 
-// Test Get function by tempering the url list with a bad URL
-func TestGetWithBadURL(t *testing.T) {
-	urls = []string{"https://example.org"}
+// failingProvider is a Provider that always returns an error, used to
+// exercise fetchFirst's all-requests-failed path without depending on any
+// real network endpoint.
+type failingProvider struct{ name string }
 
-	ip, source, err := Get()
+func (p failingProvider) Name() string { return p.name }
+
+func (p failingProvider) Fetch(ctx context.Context, client *http.Client) (net.IP, error) {
+	return nil, errors.New("simulated failure")
+}
+
+// Test fetchFirst when every candidate Provider fails
+func TestFetchFirstAllFail(t *testing.T) {
+	ip, source, err := fetchFirst(context.Background(), []Provider{failingProvider{name: "bad"}})
 
-	if net.ParseIP(ip) != nil {
-		t.Errorf("found address: %s", net.ParseIP(ip).String())
+	if ip != nil {
+		t.Errorf("found address: %s", ip.String())
 	}
 
 	if source != "" {